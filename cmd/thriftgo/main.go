@@ -0,0 +1,116 @@
+// Copyright 2021 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/cloudwego/thriftgo/parser"
+	"github.com/cloudwego/thriftgo/semantic"
+)
+
+// fixMode is a flag.Value that makes -fix behave like a boolean flag when
+// given bare (write the fix back to disk) while still accepting -fix=diff
+// to print a unified diff instead, the same way -fix is used in the
+// original request ("writes changes back or prints a unified diff").
+type fixMode struct {
+	set   bool
+	write bool
+}
+
+func (m *fixMode) String() string {
+	if !m.set {
+		return ""
+	}
+	if m.write {
+		return "true"
+	}
+	return "diff"
+}
+
+func (m *fixMode) Set(s string) error {
+	m.set = true
+	switch s {
+	case "true", "":
+		m.write = true
+	case "diff":
+		m.write = false
+	default:
+		return fmt.Errorf("invalid -fix value %q: want -fix or -fix=diff", s)
+	}
+	return nil
+}
+
+func (m *fixMode) IsBoolFlag() bool { return true }
+
+func main() {
+	var fix fixMode
+	flag.Var(&fix, "fix", "autofix warnings in-place, or print a unified diff with -fix=diff")
+	flag.Parse()
+
+	if flag.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "usage: thriftgo [-fix|-fix=diff] <file.thrift>")
+		os.Exit(2)
+	}
+	filename := flag.Arg(0)
+
+	if !fix.set {
+		fmt.Fprintln(os.Stderr, "thriftgo: nothing to do without -fix")
+		os.Exit(2)
+	}
+
+	if err := runFix(filename, fix.write); err != nil {
+		fmt.Fprintf(os.Stderr, "thriftgo: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// runFix parses filename, collects its diagnostics, and either writes the
+// autofixed source back to filename or prints a unified diff, depending on
+// write.
+func runFix(filename string, write bool) error {
+	source, err := os.ReadFile(filename)
+	if err != nil {
+		return err
+	}
+
+	t, err := parser.ParseFile(filename, nil, true)
+	if err != nil {
+		return fmt.Errorf("parsing %s: %w", filename, err)
+	}
+
+	diags, err := semantic.NewChecker(semantic.Options{}).CheckAllDiagnostics(t)
+	if err != nil {
+		return fmt.Errorf("checking %s: %w", filename, err)
+	}
+
+	fixer := semantic.NewFixer(t, source)
+	if !write {
+		diff, err := fixer.Diff(diags)
+		if err != nil {
+			return err
+		}
+		fmt.Print(diff)
+		return nil
+	}
+
+	patched, err := fixer.Fix(diags)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filename, patched, 0o644)
+}
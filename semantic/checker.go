@@ -16,8 +16,9 @@ package semantic
 
 import (
 	"fmt"
-	"log"
 	"math"
+	"runtime"
+	"sync"
 
 	"github.com/cloudwego/thriftgo/parser"
 )
@@ -26,11 +27,38 @@ import (
 // warning messages for non-fatal errors.
 type Checker interface {
 	CheckAll(t *parser.Thrift) (warns []string, err error)
+
+	// CheckAllDiagnostics is the structured counterpart of CheckAll: it runs
+	// the same checks concurrently and returns machine-readable Diagnostics
+	// instead of warning strings, for IDE/LSP integrations, Fixer, and
+	// other tooling that wants file/code/severity information.
+	CheckAllDiagnostics(t *parser.Thrift) (diags []Diagnostic, err error)
 }
 
 // Options controls the behavior of the default checker.
 type Options struct {
 	FixWarnings bool
+
+	// Registry selects which set of rules CheckAll/CheckAllDiagnostics
+	// consults. Defaults to DefaultRegistry, which holds the built-in rules
+	// (the five default-enabled grammar checks plus the opt-in style and
+	// cycles rules) plus anything third-party packages have registered via
+	// Register.
+	Registry *Registry
+
+	// EnabledRules, if non-empty, restricts checking to exactly these rule
+	// IDs. DisabledRules still wins over EnabledRules for a given ID.
+	EnabledRules []string
+
+	// DisabledRules turns off specific rule IDs, e.g. "unions" to silence
+	// union-requiredness checking entirely.
+	DisabledRules []string
+
+	// SeverityOverrides remaps the Severity of individual diagnostics by
+	// Code, e.g. {CodeNonPositiveFieldID: SeverityError} to turn a warning
+	// into a build-breaking error, or {CodeUnionRequiredField:
+	// SeverityIgnore} to silence just that one finding.
+	SeverityOverrides map[string]Severity
 }
 
 type checker struct {
@@ -47,31 +75,92 @@ func (c *checker) ResolveSymbols(t *parser.Thrift) error {
 	return ResolveSymbols(t)
 }
 
-// CheckAll implements the Checker interface.
+// CheckAll implements the Checker interface. It is kept as a thin wrapper
+// around CheckAllDiagnostics for API compatibility: warning-level
+// diagnostics become warns, and err is the fatal error CheckAllDiagnostics
+// returned, or, if there is none, the first error-level diagnostic (if
+// any).
 func (c *checker) CheckAll(t *parser.Thrift) (warns []string, err error) {
-	checks := []func(t *parser.Thrift) ([]string, error){
-		c.CheckGlobals,
-		c.CheckEnums,
-		c.CheckStructLikes,
-		c.CheckUnions,
-		c.CheckFunctions,
+	diags, err := c.CheckAllDiagnostics(t)
+	for _, d := range diags {
+		switch d.Severity {
+		case SeverityError:
+			if err == nil {
+				err = fmt.Errorf("%s", d.Message)
+			}
+		default:
+			warns = append(warns, d.Message)
+		}
 	}
+	return warns, err
+}
+
+// CheckAllDiagnostics is the new primary entry point for semantic checking.
+// It runs the per-file checks for every file reachable from t concurrently,
+// bounded by a semaphore sized to GOMAXPROCS (mirroring the approach
+// noder.LoadPackage uses to parallelize file loading), then collects the
+// results in the deterministic order files were discovered by
+// t.DepthFirstSearch().
+func (c *checker) CheckAllDiagnostics(t *parser.Thrift) (diags []Diagnostic, err error) {
+	var files []*parser.Thrift
 	for tt := range t.DepthFirstSearch() {
-		for _, f := range checks {
-			ws, err := f(tt)
-			warns = append(warns, ws...)
-			if err != nil {
-				return warns, err
-			}
+		files = append(files, tt)
+	}
+
+	type result struct {
+		diags []Diagnostic
+		err   error
+	}
+	results := make([]result, len(files))
+
+	sem := make(chan struct{}, runtime.GOMAXPROCS(0))
+	var wg sync.WaitGroup
+	for i, f := range files {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, f *parser.Thrift) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			results[i].diags, results[i].err = c.checkFile(f)
+		}(i, f)
+	}
+	wg.Wait()
+
+	for _, r := range results {
+		diags = append(diags, r.diags...)
+		if r.err != nil && err == nil {
+			err = r.err
 		}
 	}
-	return warns, nil
+	return diags, err
 }
 
-func (c *checker) CheckGlobals(t *parser.Thrift) (warns []string, err error) {
+// checkFile runs the active rules (see Options.Registry, EnabledRules and
+// DisabledRules) against a single file, stopping at the first rule that
+// reports a fatal error (matching the historical behavior of CheckAll for a
+// given file).
+func (c *checker) checkFile(t *parser.Thrift) (diags []Diagnostic, err error) {
+	for _, rule := range c.activeRules() {
+		ds, err := c.runRule(rule, t)
+		diags = append(diags, ds...)
+		if err != nil {
+			return diags, err
+		}
+	}
+	return diags, nil
+}
+
+func (c *checker) CheckGlobals(t *parser.Thrift) (diags []Diagnostic, err error) {
 	defer func() {
 		if e := recover(); e != nil {
 			err = fmt.Errorf("[IDL grammar error] duplicated names in global scope: %s from file %s", e, t.Filename)
+			diags = append(diags, Diagnostic{
+				Severity: SeverityError,
+				Code:     CodeDuplicateGlobalName,
+				File:     t.Filename,
+				Name:     fmt.Sprint(e),
+				Message:  err.Error(),
+			})
 		}
 	}()
 	globals := make(map[string]bool)
@@ -96,13 +185,22 @@ func (c *checker) CheckGlobals(t *parser.Thrift) (warns []string, err error) {
 	return
 }
 
-func (c *checker) CheckEnums(t *parser.Thrift) (warns []string, err error) {
+func (c *checker) CheckEnums(t *parser.Thrift) (diags []Diagnostic, err error) {
 	for _, e := range t.Enums {
 		exist := make(map[string]bool)
 		v2n := make(map[int64]string)
 		for _, v := range e.Values {
 			if exist[v.Name] {
 				err = fmt.Errorf("[IDL grammar error] enum %s has duplicated value: %s from file %s", e.Name, v.Name, t.Filename)
+				diags = append(diags, Diagnostic{
+					Severity: SeverityError,
+					Code:     CodeDuplicateEnumValueName,
+					File:     t.Filename,
+					Name:     v.Name,
+					Owner:    e.Name,
+					Message:  err.Error(),
+				})
+				return
 			}
 			exist[v.Name] = true
 			if n, ok := v2n[v.Value]; ok && n != v.Name {
@@ -110,21 +208,36 @@ func (c *checker) CheckEnums(t *parser.Thrift) (warns []string, err error) {
 					"[IDL grammar error] enum %s: duplicate value %d between '%s' and '%s' from file %s",
 					e.Name, v.Value, n, v.Name, t.Filename,
 				)
-			}
-			v2n[v.Value] = v.Name
-			if err != nil {
+				diags = append(diags, Diagnostic{
+					Severity: SeverityError,
+					Code:     CodeDuplicateEnumValue,
+					File:     t.Filename,
+					Name:     v.Name,
+					Owner:    e.Name,
+					Message:  err.Error(),
+				})
 				return
 			}
+			v2n[v.Value] = v.Name
 			// check if enum value can be safely converted to int 32
 			if v.Value < math.MinInt32 || v.Value > math.MaxInt32 {
-				log.Printf("the value of enum %s is %d, which exceeds the range of int32. Please adjust its value to fit within the int32 range to avoid data errors during serialization!!!\n", v.Name, v.Value)
+				diags = append(diags, Diagnostic{
+					Severity: SeverityWarning,
+					Code:     CodeEnumValueOverflow,
+					File:     t.Filename,
+					Name:     v.Name,
+					Owner:    e.Name,
+					Message: fmt.Sprintf(
+						"the value of enum %s is %d, which exceeds the range of int32. Please adjust its value to fit within the int32 range to avoid data errors during serialization!!!",
+						v.Name, v.Value),
+				})
 			}
 		}
 	}
 	return
 }
 
-func (c *checker) CheckStructLikes(t *parser.Thrift) (warns []string, err error) {
+func (c *checker) CheckStructLikes(t *parser.Thrift) (diags []Diagnostic, err error) {
 	for _, s := range t.GetStructLikes() {
 		fieldIDs := make(map[int32]bool)
 		names := make(map[string]bool)
@@ -132,18 +245,41 @@ func (c *checker) CheckStructLikes(t *parser.Thrift) (warns []string, err error)
 			if fieldIDs[f.ID] {
 				err = fmt.Errorf("[IDL grammar error] duplicated field ID %d in %s %q from file %s",
 					f.ID, s.Category, s.Name, t.Filename)
+				diags = append(diags, Diagnostic{
+					Severity: SeverityError,
+					Code:     CodeDuplicateFieldID,
+					File:     t.Filename,
+					Name:     f.Name,
+					Owner:    s.Name,
+					Message:  err.Error(),
+				})
 				return
 			}
 			if names[f.Name] {
 				err = fmt.Errorf("[IDL grammar error] duplicated field name %q in %s %q from file %s",
 					f.Name, s.Category, s.Name, t.Filename)
+				diags = append(diags, Diagnostic{
+					Severity: SeverityError,
+					Code:     CodeDuplicateFieldName,
+					File:     t.Filename,
+					Name:     f.Name,
+					Owner:    s.Name,
+					Message:  err.Error(),
+				})
 				return
 			}
 			fieldIDs[f.ID] = true
 			names[f.Name] = true
 			if f.ID <= 0 {
-				warns = append(warns, fmt.Sprintf("non-positive ID %d of field %q in %q  from file %s",
-					f.ID, f.Name, s.Name, t.Filename))
+				diags = append(diags, Diagnostic{
+					Severity: SeverityWarning,
+					Code:     CodeNonPositiveFieldID,
+					File:     t.Filename,
+					Name:     f.Name,
+					Owner:    s.Name,
+					Message: fmt.Sprintf("non-positive ID %d of field %q in %q  from file %s",
+						f.ID, f.Name, s.Name, t.Filename),
+				})
 			}
 		}
 	}
@@ -151,21 +287,35 @@ func (c *checker) CheckStructLikes(t *parser.Thrift) (warns []string, err error)
 }
 
 // CheckUnions checks the semantics of union nodes.
-func (c *checker) CheckUnions(t *parser.Thrift) (warns []string, err error) {
+func (c *checker) CheckUnions(t *parser.Thrift) (diags []Diagnostic, err error) {
 	for _, u := range t.Unions {
 		var hasDefault bool
 		for _, f := range u.Fields {
 			if f.Requiredness == parser.FieldType_Required {
-				msg := fmt.Sprintf(
-					"union %s field %s: union members must be optional, ignoring specified requiredness.",
-					u.Name, f.Name)
-				warns = append(warns, msg)
+				diags = append(diags, Diagnostic{
+					Severity: SeverityWarning,
+					Code:     CodeUnionRequiredField,
+					File:     t.Filename,
+					Name:     f.Name,
+					Owner:    u.Name,
+					Message: fmt.Sprintf(
+						"union %s field %s: union members must be optional, ignoring specified requiredness.",
+						u.Name, f.Name),
+				})
 			}
 
 			if f.GetDefault() != nil {
 				if hasDefault {
 					err = fmt.Errorf("[IDL grammar error] field %s provides another default value for union %s from file %s", f.Name, u.Name, t.Filename)
-					return warns, err
+					diags = append(diags, Diagnostic{
+						Severity: SeverityError,
+						Code:     CodeUnionMultipleDefaults,
+						File:     t.Filename,
+						Name:     f.Name,
+						Owner:    u.Name,
+						Message:  err.Error(),
+					})
+					return diags, err
 				}
 			}
 
@@ -178,42 +328,83 @@ func (c *checker) CheckUnions(t *parser.Thrift) (warns []string, err error) {
 }
 
 // CheckFunctions checks the semantics of service functions.
-func (c *checker) CheckFunctions(t *parser.Thrift) (warns []string, err error) {
-	var argOpt string
+func (c *checker) CheckFunctions(t *parser.Thrift) (diags []Diagnostic, err error) {
+	var argOpt, argOptName, argOptOwner, argOptScope string
 	for _, svc := range t.Services {
 		defined := make(map[string]bool)
 		for _, f := range svc.Functions {
 			if defined[f.Name] {
 				err = fmt.Errorf("[IDL grammar error] duplicated function name in %q: %q from file %s", svc.Name, f.Name, t.Filename)
-				return
+				diags = append(diags, Diagnostic{
+					Severity: SeverityError,
+					Code:     CodeDuplicateFunctionName,
+					File:     t.Filename,
+					Name:     f.Name,
+					Owner:    svc.Name,
+					Message:  err.Error(),
+				})
+				return diags, err
 			}
 			defined[f.Name] = true
 
 			if f.Oneway && !f.Void {
 				err = fmt.Errorf("[IDL grammar error] %s.%s: oneway function must be void type from file %s", svc.Name, f.Name, t.Filename)
-				return
+				diags = append(diags, Diagnostic{
+					Severity: SeverityError,
+					Code:     CodeOnewayNotVoid,
+					File:     t.Filename,
+					Name:     f.Name,
+					Owner:    svc.Name,
+					Message:  err.Error(),
+				})
+				return diags, err
 			}
 			if f.Oneway && len(f.Throws) > 0 {
 				err = fmt.Errorf("[IDL grammar error] %s.%s: oneway methods can't throw exceptions from file %s", svc.Name, f.Name, t.Filename)
-				return
+				diags = append(diags, Diagnostic{
+					Severity: SeverityError,
+					Code:     CodeOnewayThrows,
+					File:     t.Filename,
+					Name:     f.Name,
+					Owner:    svc.Name,
+					Message:  err.Error(),
+				})
+				return diags, err
 			}
 			for _, a := range f.Arguments {
 				if a.Requiredness == parser.FieldType_Optional {
 					argOpt = t.Filename + ": optional keyword is ignored in argument lists."
+					argOptName, argOptOwner, argOptScope = a.Name, svc.Name, f.Name
 					if c.FixWarnings {
 						a.Requiredness = parser.FieldType_Default
 					}
 				}
 				if a.ID <= 0 {
-					warns = append(warns, fmt.Sprintf("non-positive ID %d of argument %q in %q.%q",
-						a.ID, a.Name, svc.Name, f.Name))
+					diags = append(diags, Diagnostic{
+						Severity: SeverityWarning,
+						Code:     CodeNonPositiveArgID,
+						File:     t.Filename,
+						Name:     a.Name,
+						Owner:    svc.Name,
+						Scope:    f.Name,
+						Message: fmt.Sprintf("non-positive ID %d of argument %q in %q.%q",
+							a.ID, a.Name, svc.Name, f.Name),
+					})
 				}
 			}
 			for _, a := range f.Throws {
 				switch a.Requiredness {
 				case parser.FieldType_Required:
-					warns = append(warns, fmt.Sprintf("exception %q in %q.%q: throw field must be optional, ignoring specified requiredness.",
-						a.Name, svc.Name, f.Name))
+					diags = append(diags, Diagnostic{
+						Severity: SeverityWarning,
+						Code:     CodeThrowsFieldRequired,
+						File:     t.Filename,
+						Name:     a.Name,
+						Owner:    svc.Name,
+						Scope:    f.Name,
+						Message: fmt.Sprintf("exception %q in %q.%q: throw field must be optional, ignoring specified requiredness.",
+							a.Name, svc.Name, f.Name),
+					})
 					if !c.FixWarnings {
 						continue
 					}
@@ -225,7 +416,15 @@ func (c *checker) CheckFunctions(t *parser.Thrift) (warns []string, err error) {
 		}
 	}
 	if argOpt != "" {
-		warns = append(warns, argOpt)
+		diags = append(diags, Diagnostic{
+			Severity: SeverityWarning,
+			Code:     CodeOptionalArgument,
+			File:     t.Filename,
+			Name:     argOptName,
+			Owner:    argOptOwner,
+			Scope:    argOptScope,
+			Message:  argOpt,
+		})
 	}
 	return
 }
@@ -0,0 +1,196 @@
+// Copyright 2021 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package semantic
+
+import (
+	"testing"
+
+	"github.com/cloudwego/thriftgo/parser"
+)
+
+// TestCheckAllDiagnosticsViaInterface guards against the Checker interface
+// regressing to only expose CheckAll: CheckAllDiagnostics must be callable
+// through a Checker value, not just the unexported *checker type.
+func TestCheckAllDiagnosticsViaInterface(t *testing.T) {
+	var c Checker = NewChecker(Options{})
+
+	th := &parser.Thrift{
+		Filename: "clean.thrift",
+		Structs: []*parser.StructLike{
+			{Category: "struct", Name: "Foo", Fields: []*parser.Field{
+				{ID: 1, Name: "bar"},
+			}},
+		},
+	}
+
+	diags, err := c.CheckAllDiagnostics(th)
+	if err != nil {
+		t.Fatalf("CheckAllDiagnostics: unexpected error: %v", err)
+	}
+	if len(diags) != 0 {
+		t.Fatalf("CheckAllDiagnostics: expected no diagnostics for a clean file, got %v", diags)
+	}
+}
+
+// TestCheckAllSplitsBySeverity exercises the warning side of CheckAll's role
+// as a thin wrapper around CheckAllDiagnostics: a warning-level diagnostic
+// must land in warns and must not become err.
+func TestCheckAllSplitsBySeverity(t *testing.T) {
+	th := &parser.Thrift{
+		Filename: "mixed.thrift",
+		Structs: []*parser.StructLike{
+			{Category: "struct", Name: "Foo", Fields: []*parser.Field{
+				{ID: 0, Name: "bar"}, // non-positive ID -> warning
+			}},
+		},
+	}
+
+	c := NewChecker(Options{})
+	warns, err := c.CheckAll(th)
+	if err != nil {
+		t.Fatalf("CheckAll: unexpected error: %v", err)
+	}
+	if len(warns) != 1 {
+		t.Fatalf("CheckAll: expected 1 warning, got %d (%v)", len(warns), warns)
+	}
+}
+
+// TestGrammarErrorsEmitStructuredDiagnostics guards against the fatal/
+// grammar-error paths silently staying unstructured fmt.Errorf strings: each
+// one must also append a SeverityError Diagnostic under its own Code, not
+// just set err, so CheckAllDiagnostics callers (IDE/LSP tooling, Fixer) get
+// machine-readable data for every finding, not just the warnings.
+func TestGrammarErrorsEmitStructuredDiagnostics(t *testing.T) {
+	cases := []struct {
+		name string
+		th   *parser.Thrift
+		code string
+	}{
+		{
+			name: "duplicate global name",
+			th: &parser.Thrift{
+				Filename:  "x.thrift",
+				Typedefs:  []*parser.Typedef{{Alias: "Dup", Type: &parser.Type{Name: "string"}}},
+				Constants: []*parser.Constant{{Name: "Dup"}},
+			},
+			code: CodeDuplicateGlobalName,
+		},
+		{
+			name: "duplicate enum value name",
+			th: &parser.Thrift{
+				Filename: "x.thrift",
+				Enums: []*parser.Enum{{Name: "E", Values: []*parser.EnumValue{
+					{Name: "A", Value: 1},
+					{Name: "A", Value: 2},
+				}}},
+			},
+			code: CodeDuplicateEnumValueName,
+		},
+		{
+			name: "duplicate enum value",
+			th: &parser.Thrift{
+				Filename: "x.thrift",
+				Enums: []*parser.Enum{{Name: "E", Values: []*parser.EnumValue{
+					{Name: "A", Value: 1},
+					{Name: "B", Value: 1},
+				}}},
+			},
+			code: CodeDuplicateEnumValue,
+		},
+		{
+			name: "duplicate field ID",
+			th: &parser.Thrift{
+				Filename: "x.thrift",
+				Structs: []*parser.StructLike{{Category: "struct", Name: "S", Fields: []*parser.Field{
+					{ID: 1, Name: "a"},
+					{ID: 1, Name: "b"},
+				}}},
+			},
+			code: CodeDuplicateFieldID,
+		},
+		{
+			name: "duplicate field name",
+			th: &parser.Thrift{
+				Filename: "x.thrift",
+				Structs: []*parser.StructLike{{Category: "struct", Name: "S", Fields: []*parser.Field{
+					{ID: 1, Name: "a"},
+					{ID: 2, Name: "a"},
+				}}},
+			},
+			code: CodeDuplicateFieldName,
+		},
+		{
+			name: "duplicate function name",
+			th: &parser.Thrift{
+				Filename: "x.thrift",
+				Services: []*parser.Service{{Name: "Svc", Functions: []*parser.Function{
+					{Name: "m", Void: true},
+					{Name: "m", Void: true},
+				}}},
+			},
+			code: CodeDuplicateFunctionName,
+		},
+		{
+			name: "oneway not void",
+			th: &parser.Thrift{
+				Filename: "x.thrift",
+				Services: []*parser.Service{{Name: "Svc", Functions: []*parser.Function{
+					{Name: "m", Oneway: true, Void: false},
+				}}},
+			},
+			code: CodeOnewayNotVoid,
+		},
+		{
+			name: "oneway throws",
+			th: &parser.Thrift{
+				Filename: "x.thrift",
+				Services: []*parser.Service{{Name: "Svc", Functions: []*parser.Function{
+					{Name: "m", Oneway: true, Void: true, Throws: []*parser.Field{{ID: 1, Name: "e"}}},
+				}}},
+			},
+			code: CodeOnewayThrows,
+		},
+	}
+
+	c := &checker{}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			var diags []Diagnostic
+			var err error
+			switch tc.code {
+			case CodeDuplicateGlobalName:
+				diags, err = c.CheckGlobals(tc.th)
+			case CodeDuplicateEnumValueName, CodeDuplicateEnumValue:
+				diags, err = c.CheckEnums(tc.th)
+			case CodeDuplicateFieldID, CodeDuplicateFieldName:
+				diags, err = c.CheckStructLikes(tc.th)
+			default:
+				diags, err = c.CheckFunctions(tc.th)
+			}
+			if err == nil {
+				t.Fatalf("%s: expected a fatal error", tc.name)
+			}
+			var found bool
+			for _, d := range diags {
+				if d.Code == tc.code && d.Severity == SeverityError {
+					found = true
+				}
+			}
+			if !found {
+				t.Fatalf("%s: expected a SeverityError diagnostic with code %s, got %v", tc.name, tc.code, diags)
+			}
+		})
+	}
+}
@@ -0,0 +1,354 @@
+// Copyright 2021 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package semantic
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/cloudwego/thriftgo/parser"
+)
+
+const (
+	CodeSelfReferentialStruct = "SEM201_SelfReferentialStruct"
+	CodeRequiredFieldCycle    = "SEM202_RequiredFieldCycle"
+	CodeIncludeCycle          = "SEM203_IncludeCycle"
+)
+
+// CheckCycles detects recursive type definitions reachable from t: struct
+// fields and typedef aliases that eventually refer back to their own
+// declaration, and include cycles across the files t.DepthFirstSearch()
+// walks (which, unlike this check, silently tolerates them).
+//
+// A cycle made up entirely of required fields is reported as an error,
+// since a required field can't be represented without indirection in every
+// target language, and in ones that can't would recurse without end. Any
+// other cycle (one that passes through at least one optional or
+// default-requiredness field, or a typedef alias) is reported as a warning:
+// a struct that optionally contains itself (directly or transitively) is
+// legal, since generated code represents it with a pointer or equivalent
+// indirection.
+//
+// ResolveSymbols is the global function with the same name; CheckCycles
+// follows the same convention.
+func CheckCycles(t *parser.Thrift) (diags []Diagnostic, err error) {
+	diags = append(diags, checkTypeCycles(t)...)
+	diags = append(diags, checkIncludeCycles(t)...)
+	return diags, nil
+}
+
+// CheckCycles forwards to the package-level function.
+func (c *checker) CheckCycles(t *parser.Thrift) ([]Diagnostic, error) {
+	return CheckCycles(t)
+}
+
+// cyclesRule adapts CheckCycles to the Rule interface. It's opt-in (like the
+// style rules in style_rules.go) rather than on by default, since a self-
+// referential struct is sometimes intentional (e.g. a tree node) and
+// existing IDLs that rely on FixWarnings-free CheckAll shouldn't start
+// reporting errors on an upgrade.
+type cyclesRule struct{}
+
+func (cyclesRule) ID() string          { return "cycles" }
+func (cyclesRule) Description() string { return "recursive type definitions and include cycles" }
+func (cyclesRule) DefaultEnabled() bool { return false }
+func (cyclesRule) Check(t *parser.Thrift, emit func(Diagnostic)) error {
+	diags, err := CheckCycles(t)
+	for _, d := range diags {
+		emit(d)
+	}
+	return err
+}
+
+func init() {
+	Register(cyclesRule{})
+}
+
+// typeEdge is a directed edge in the struct/typedef reference graph: from
+// references to, and required is true when the edge comes from a struct
+// field declared required (as opposed to a typedef alias, or an optional
+// or default field).
+type typeEdge struct {
+	to       string
+	required bool
+}
+
+// directRef returns the name of the struct-like or typedef typ directly
+// names, ignoring containers: list<Foo>, set<Foo> and map<K,Foo> always go
+// through language-level indirection, so they can never cause the
+// infinite-size problem this check cares about.
+func directRef(typ *parser.Type) (string, bool) {
+	if typ == nil || typ.KeyType != nil || typ.ValueType != nil {
+		return "", false
+	}
+	return typ.Name, typ.Name != ""
+}
+
+// checkTypeCycles builds the struct-field and typedef-alias graph for t and
+// reports every non-trivial strongly connected component via Tarjan's
+// algorithm.
+func checkTypeCycles(t *parser.Thrift) (diags []Diagnostic) {
+	edges := make(map[string][]typeEdge)
+	declared := make(map[string]bool)
+
+	for _, s := range t.GetStructLikes() {
+		declared[s.Name] = true
+		for _, f := range s.Fields {
+			if to, ok := directRef(f.Type); ok {
+				edges[s.Name] = append(edges[s.Name], typeEdge{
+					to:       to,
+					required: f.Requiredness == parser.FieldType_Required,
+				})
+			}
+		}
+	}
+	for _, td := range t.Typedefs {
+		declared[td.Alias] = true
+		if to, ok := directRef(td.Type); ok {
+			edges[td.Alias] = append(edges[td.Alias], typeEdge{to: to})
+		}
+	}
+
+	nodes := make([]string, 0, len(declared))
+	for name := range declared {
+		nodes = append(nodes, name)
+	}
+
+	for _, scc := range tarjanSCC(nodes, edges) {
+		if len(scc) == 0 {
+			continue
+		}
+		if len(scc) == 1 && !hasSelfEdge(edges, scc[0]) {
+			continue
+		}
+		diags = append(diags, cycleDiagnostic(t, scc, edges))
+	}
+	return diags
+}
+
+func hasSelfEdge(edges map[string][]typeEdge, name string) bool {
+	for _, e := range edges[name] {
+		if e.to == name {
+			return true
+		}
+	}
+	return false
+}
+
+// cycleDiagnostic renders scc (a set of mutually reachable names) into a
+// Diagnostic, walking the cycle back into a readable path and picking Error
+// severity if scc contains a cycle made up entirely of required edges (not
+// merely if the first path reached by a walk happens to use one): an SCC
+// can contain more than one cycle mixing optional and required edges, and
+// severity must reflect the worst cycle present, not whichever one a single
+// arbitrary walk finds first.
+func cycleDiagnostic(t *parser.Thrift, scc []string, edges map[string][]typeEdge) Diagnostic {
+	inSCC := make(map[string]bool, len(scc))
+	for _, n := range scc {
+		inSCC[n] = true
+	}
+
+	severity := SeverityWarning
+	code := CodeSelfReferentialStruct
+	if hasRequiredCycle(scc, inSCC, edges) {
+		severity = SeverityError
+		code = CodeRequiredFieldCycle
+	}
+
+	start := startNode(scc, inSCC, edges, severity == SeverityError)
+	path := []string{start}
+	cur := start
+	for len(path) <= len(scc) {
+		var next *typeEdge
+		for i, e := range edges[cur] {
+			if !inSCC[e.to] {
+				continue
+			}
+			if severity == SeverityError && !e.required {
+				continue
+			}
+			next = &edges[cur][i]
+			break
+		}
+		if next == nil {
+			break
+		}
+		path = append(path, next.to)
+		cur = next.to
+		if cur == start {
+			break
+		}
+	}
+
+	kind := "optional field(s), which is legal"
+	if severity == SeverityError {
+		kind = "required fields only, which would recurse without end at runtime"
+	}
+	return Diagnostic{
+		Severity: severity,
+		Code:     code,
+		File:     t.Filename,
+		Message: fmt.Sprintf("recursive type definition via %s: %s",
+			kind, strings.Join(path, " -> ")),
+	}
+}
+
+// hasRequiredCycle reports whether scc contains a cycle made up entirely of
+// required edges, by running Tarjan's algorithm again over the subgraph
+// restricted to edges between scc members that are required. Checking only
+// the first path a walk happens to reach isn't enough: an SCC can contain
+// several distinct cycles mixing optional and required edges, and the
+// severity has to reflect the worst one.
+func hasRequiredCycle(scc []string, inSCC map[string]bool, edges map[string][]typeEdge) bool {
+	required := make(map[string][]typeEdge)
+	for _, n := range scc {
+		for _, e := range edges[n] {
+			if e.required && inSCC[e.to] {
+				required[n] = append(required[n], e)
+			}
+		}
+	}
+	for _, sub := range tarjanSCC(scc, required) {
+		if len(sub) > 1 {
+			return true
+		}
+		if len(sub) == 1 && hasSelfEdge(required, sub[0]) {
+			return true
+		}
+	}
+	return false
+}
+
+// startNode picks where cycleDiagnostic's path walk begins: scc[0] for a
+// warning, since any member works to illustrate an all-legal cycle, or the
+// first scc member with an outgoing required edge to another scc member
+// when requiredOnly is set, so the rendered path actually traces the
+// required cycle that earned the Error severity instead of possibly
+// starting from a node that isn't part of it.
+func startNode(scc []string, inSCC map[string]bool, edges map[string][]typeEdge, requiredOnly bool) string {
+	if requiredOnly {
+		for _, n := range scc {
+			for _, e := range edges[n] {
+				if e.required && inSCC[e.to] {
+					return n
+				}
+			}
+		}
+	}
+	return scc[0]
+}
+
+// tarjanSCC runs Tarjan's strongly-connected-components algorithm over the
+// graph described by nodes and edges, returning one []string per component
+// in the order their roots finish (reverse topological order), which is
+// deterministic for a given nodes order.
+func tarjanSCC(nodes []string, edges map[string][]typeEdge) [][]string {
+	index := make(map[string]int)
+	lowlink := make(map[string]int)
+	onStack := make(map[string]bool)
+	var stack []string
+	var sccs [][]string
+	next := 0
+
+	var strongconnect func(v string)
+	strongconnect = func(v string) {
+		index[v] = next
+		lowlink[v] = next
+		next++
+		stack = append(stack, v)
+		onStack[v] = true
+
+		for _, e := range edges[v] {
+			w := e.to
+			// w may be a scalar or a type declared in another file, neither
+			// of which has entries in edges; that's fine, it just becomes a
+			// trivial, edge-less SCC of its own that gets filtered out
+			// below.
+			if _, ok := index[w]; !ok {
+				strongconnect(w)
+				if lowlink[w] < lowlink[v] {
+					lowlink[v] = lowlink[w]
+				}
+			} else if onStack[w] {
+				if index[w] < lowlink[v] {
+					lowlink[v] = index[w]
+				}
+			}
+		}
+
+		if lowlink[v] == index[v] {
+			var scc []string
+			for {
+				n := len(stack) - 1
+				w := stack[n]
+				stack = stack[:n]
+				onStack[w] = false
+				scc = append(scc, w)
+				if w == v {
+					break
+				}
+			}
+			sccs = append(sccs, scc)
+		}
+	}
+
+	for _, v := range nodes {
+		if _, ok := index[v]; !ok {
+			strongconnect(v)
+		}
+	}
+	return sccs
+}
+
+// checkIncludeCycles walks the include graph reachable from t looking for a
+// file that (transitively) includes itself, which
+// t.DepthFirstSearch() doesn't detect on its own.
+func checkIncludeCycles(t *parser.Thrift) (diags []Diagnostic) {
+	visiting := make(map[string]bool)
+	visited := make(map[string]bool)
+	var path []string
+
+	var walk func(cur *parser.Thrift)
+	walk = func(cur *parser.Thrift) {
+		if visited[cur.Filename] {
+			return
+		}
+		if visiting[cur.Filename] {
+			path = append(path, cur.Filename)
+			diags = append(diags, Diagnostic{
+				Severity: SeverityError,
+				Code:     CodeIncludeCycle,
+				File:     t.Filename,
+				Message:  "include cycle: " + strings.Join(path, " -> "),
+			})
+			path = path[:len(path)-1]
+			return
+		}
+
+		visiting[cur.Filename] = true
+		path = append(path, cur.Filename)
+		for _, inc := range cur.Includes {
+			if inc.Reference != nil {
+				walk(inc.Reference)
+			}
+		}
+		path = path[:len(path)-1]
+		visiting[cur.Filename] = false
+		visited[cur.Filename] = true
+	}
+
+	walk(t)
+	return diags
+}
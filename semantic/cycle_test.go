@@ -0,0 +1,152 @@
+// Copyright 2021 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package semantic
+
+import (
+	"testing"
+
+	"github.com/cloudwego/thriftgo/parser"
+)
+
+func TestTarjanSCCFindsCycle(t *testing.T) {
+	edges := map[string][]typeEdge{
+		"A": {{to: "B"}},
+		"B": {{to: "A"}},
+		"C": {{to: "A"}},
+	}
+	sccs := tarjanSCC([]string{"A", "B", "C"}, edges)
+
+	var found bool
+	for _, scc := range sccs {
+		if len(scc) == 2 {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("tarjanSCC: expected a 2-node SCC for A<->B, got %v", sccs)
+	}
+}
+
+func structField(name string, req parser.FieldType, typeName string) *parser.Field {
+	return &parser.Field{
+		ID:           1,
+		Name:         name,
+		Requiredness: req,
+		Type:         &parser.Type{Name: typeName},
+	}
+}
+
+func TestCheckCyclesOptionalSelfReferenceIsWarning(t *testing.T) {
+	th := &parser.Thrift{
+		Filename: "x.thrift",
+		Structs: []*parser.StructLike{
+			{Category: "struct", Name: "Node", Fields: []*parser.Field{
+				structField("child", parser.FieldType_Optional, "Node"),
+			}},
+		},
+	}
+
+	diags, err := CheckCycles(th)
+	if err != nil {
+		t.Fatalf("CheckCycles: unexpected error: %v", err)
+	}
+	if len(diags) != 1 || diags[0].Code != CodeSelfReferentialStruct || diags[0].Severity != SeverityWarning {
+		t.Fatalf("CheckCycles: expected one warning-level self-referential-struct diagnostic, got %v", diags)
+	}
+}
+
+func TestCheckCyclesRequiredFieldCycleIsError(t *testing.T) {
+	th := &parser.Thrift{
+		Filename: "x.thrift",
+		Structs: []*parser.StructLike{
+			{Category: "struct", Name: "A", Fields: []*parser.Field{
+				structField("b", parser.FieldType_Required, "B"),
+			}},
+			{Category: "struct", Name: "B", Fields: []*parser.Field{
+				structField("a", parser.FieldType_Required, "A"),
+			}},
+		},
+	}
+
+	diags, err := CheckCycles(th)
+	if err != nil {
+		t.Fatalf("CheckCycles: unexpected error: %v", err)
+	}
+	if len(diags) != 1 || diags[0].Code != CodeRequiredFieldCycle || diags[0].Severity != SeverityError {
+		t.Fatalf("CheckCycles: expected one error-level required-field-cycle diagnostic, got %v", diags)
+	}
+}
+
+// TestCheckCyclesMixedSCCPicksWorstCycle covers an SCC containing more than
+// one cycle, mixing optional and required edges: A's first-declared field
+// is an optional edge to B (which points back to A, also optionally), and
+// A's second field is a required edge to C (which points back to A
+// required). All three land in one SCC, but only the A<->C sub-cycle is
+// fully required; severity must reflect that even though the A<->B
+// sub-cycle is found first by declaration order.
+func TestCheckCyclesMixedSCCPicksWorstCycle(t *testing.T) {
+	th := &parser.Thrift{
+		Filename: "x.thrift",
+		Structs: []*parser.StructLike{
+			{Category: "struct", Name: "A", Fields: []*parser.Field{
+				structField("b", parser.FieldType_Optional, "B"),
+				{ID: 2, Name: "c", Requiredness: parser.FieldType_Required, Type: &parser.Type{Name: "C"}},
+			}},
+			{Category: "struct", Name: "B", Fields: []*parser.Field{
+				structField("a", parser.FieldType_Optional, "A"),
+			}},
+			{Category: "struct", Name: "C", Fields: []*parser.Field{
+				structField("a", parser.FieldType_Required, "A"),
+			}},
+		},
+	}
+
+	diags, err := CheckCycles(th)
+	if err != nil {
+		t.Fatalf("CheckCycles: unexpected error: %v", err)
+	}
+	if len(diags) != 1 || diags[0].Code != CodeRequiredFieldCycle || diags[0].Severity != SeverityError {
+		t.Fatalf("CheckCycles: expected the fully-required A<->C sub-cycle to win severity, got %v", diags)
+	}
+}
+
+func TestCheckCyclesIncludeCycle(t *testing.T) {
+	a := &parser.Thrift{Filename: "a.thrift"}
+	b := &parser.Thrift{Filename: "b.thrift"}
+	a.Includes = []*parser.Include{{Path: "b.thrift", Reference: b}}
+	b.Includes = []*parser.Include{{Path: "a.thrift", Reference: a}}
+
+	diags, err := CheckCycles(a)
+	if err != nil {
+		t.Fatalf("CheckCycles: unexpected error: %v", err)
+	}
+	if len(diags) != 1 || diags[0].Code != CodeIncludeCycle {
+		t.Fatalf("CheckCycles: expected one include-cycle diagnostic, got %v", diags)
+	}
+}
+
+// TestCyclesRuleIsOptIn guards the registry-wiring fix: cycles must be
+// reachable as a rule, but not run unless explicitly enabled, matching the
+// other opt-in style rules.
+func TestCyclesRuleIsOptIn(t *testing.T) {
+	rule, ok := DefaultRegistry.Lookup("cycles")
+	if !ok {
+		t.Fatal("DefaultRegistry: expected \"cycles\" to be registered")
+	}
+	opt, ok := rule.(interface{ DefaultEnabled() bool })
+	if !ok || opt.DefaultEnabled() {
+		t.Fatal("cycles rule: expected DefaultEnabled() to report false")
+	}
+}
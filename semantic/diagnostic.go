@@ -0,0 +1,105 @@
+// Copyright 2021 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package semantic
+
+import "fmt"
+
+// Severity classifies how serious a Diagnostic is.
+type Severity int
+
+// The severities a Diagnostic can carry. Error-level diagnostics are the
+// structured counterpart of the errors CheckAll used to return; Warning-level
+// diagnostics are the counterpart of its warns slice. SeverityIgnore is only
+// ever produced via Options.SeverityOverrides, to silence a specific rule's
+// finding without disabling the whole rule.
+const (
+	SeverityError Severity = iota
+	SeverityWarning
+	SeverityIgnore
+)
+
+// String implements fmt.Stringer.
+func (s Severity) String() string {
+	switch s {
+	case SeverityError:
+		return "error"
+	case SeverityIgnore:
+		return "ignore"
+	case SeverityWarning:
+		return "warning"
+	default:
+		return "unknown"
+	}
+}
+
+// Diagnostic codes for the built-in checks. The numbering has no meaning
+// beyond uniqueness; new codes must be appended, never renumbered, so that
+// tooling keyed on Code remains stable across thriftgo versions.
+const (
+	CodeDuplicateGlobalName    = "SEM001_DupGlobalName"
+	CodeDuplicateEnumValueName = "SEM002_DupEnumValueName"
+	CodeDuplicateEnumValue     = "SEM003_DupEnumValue"
+	CodeEnumValueOverflow      = "SEM004_EnumValueOverflow"
+	CodeDuplicateFieldID       = "SEM005_DupFieldID"
+	CodeDuplicateFieldName     = "SEM006_DupFieldName"
+	CodeNonPositiveFieldID     = "SEM007_NonPositiveFieldID"
+	CodeUnionRequiredField     = "SEM008_UnionRequiredField"
+	CodeUnionMultipleDefaults  = "SEM009_UnionMultipleDefaults"
+	CodeDuplicateFunctionName  = "SEM010_DupFunctionName"
+	CodeOnewayNotVoid          = "SEM011_OnewayNotVoid"
+	CodeOnewayThrows           = "SEM012_OnewayThrows"
+	CodeOptionalArgument       = "SEM013_OptionalArgument"
+	CodeNonPositiveArgID       = "SEM014_NonPositiveArgID"
+	CodeThrowsFieldRequired    = "SEM015_ThrowsFieldRequired"
+)
+
+// Diagnostic is a single, structured finding produced while checking a
+// Thrift AST. It is the machine-readable counterpart of the plain warning
+// strings and errors the checker used to produce, intended for consumption
+// by IDE/LSP integrations and other tooling that wants file, code and
+// severity information instead of free-form text. It carries no line or
+// column (see String).
+type Diagnostic struct {
+	Severity Severity
+	Code     string
+	File     string
+	Message  string
+
+	// Name is the identifier the diagnostic is about (a field, argument,
+	// enum value, function, ...), if it is about a single named thing.
+	// Fixer uses it together with Owner (and Scope, for function-scoped
+	// diagnostics) to locate the declaration in the original source.
+	Name string
+	// Owner is the enclosing struct/union/exception/enum/service name, if
+	// any.
+	Owner string
+	// Scope is the enclosing function name, for diagnostics about a
+	// function's arguments or throws clause (Owner is then the service
+	// name). Empty for diagnostics whose Owner is already the innermost
+	// container, e.g. a struct field.
+	Scope string
+}
+
+// String renders the diagnostic roughly the way the old warning/error
+// strings read, so tools that just log it see familiar output.
+//
+// There's no Line/Column here: thriftgo's AST nodes don't carry byte/line
+// positions today, so a Diagnostic can only point at File plus the
+// Name/Owner/Scope triple Fixer uses to relocate the declaration in source.
+// Add position info once there's a real source for it instead of stubbing
+// it out with zero values.
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("[%s] %s: %s (%s)", d.Severity, d.File, d.Message, d.Code)
+}
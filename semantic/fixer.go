@@ -0,0 +1,324 @@
+// Copyright 2021 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package semantic
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/cloudwego/thriftgo/parser"
+)
+
+// Fixer rewrites the on-disk .thrift source to resolve fixable diagnostics,
+// instead of only mutating the parsed AST the way Options.FixWarnings does.
+// It is deliberately line-oriented rather than offset-oriented: thriftgo's
+// AST nodes don't carry byte/line positions today, so Fixer locates the
+// declaration a Diagnostic is about by searching for its enclosing block
+// (Diagnostic.Owner, plus Diagnostic.Scope for a function's arguments or
+// throws clause) and then the declaration line within it (Diagnostic.Name),
+// the same way a human reviewer would.
+type Fixer struct {
+	Thrift *parser.Thrift
+	Source []byte
+}
+
+// NewFixer creates a Fixer for t's original source.
+func NewFixer(t *parser.Thrift, source []byte) *Fixer {
+	return &Fixer{Thrift: t, Source: source}
+}
+
+// fixableCodes lists the Diagnostic.Code values Fix knows how to resolve.
+var fixableCodes = map[string]bool{
+	CodeUnionRequiredField:  true,
+	CodeThrowsFieldRequired: true,
+	CodeOptionalArgument:    true,
+	CodeNonPositiveFieldID:  true,
+	CodeNonPositiveArgID:    true,
+}
+
+// Fix computes the patched source for diags plus any duplicate enum values
+// found directly in f.Thrift. Duplicate enum values are a fatal grammar
+// error: CheckEnums reports only the first colliding pair (as a
+// CodeDuplicateEnumValue diagnostic) and returns, so Fix ignores that code
+// in diags and looks for duplicates independently, because picking a
+// replacement value requires knowing every value already in use, not just
+// the one colliding pair CheckEnums stopped at.
+//
+// Diagnostics whose Code isn't fixable, or whose declaration can't be found
+// in Source, are skipped rather than treated as errors: autofix is
+// best-effort, and a partial fix is still useful.
+func (f *Fixer) Fix(diags []Diagnostic) ([]byte, error) {
+	lines := strings.Split(string(f.Source), "\n")
+
+	for _, d := range diags {
+		if !fixableCodes[d.Code] {
+			continue
+		}
+		if err := f.applyFix(lines, d); err != nil {
+			return nil, err
+		}
+	}
+	if err := f.fixDuplicateEnumValues(lines); err != nil {
+		return nil, err
+	}
+
+	return []byte(strings.Join(lines, "\n")), nil
+}
+
+// Diff renders a simple unified-diff-style listing of the lines Fix would
+// change, for `thriftgo -fix=diff`. It's line-granular, not a minimal edit
+// script, since Fixer's edits never touch line boundaries.
+func (f *Fixer) Diff(diags []Diagnostic) (string, error) {
+	original := strings.Split(string(f.Source), "\n")
+	patched, err := f.Fix(diags)
+	if err != nil {
+		return "", err
+	}
+	patchedLines := strings.Split(string(patched), "\n")
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "--- %s\n+++ %s\n", f.Thrift.Filename, f.Thrift.Filename)
+	for i := range original {
+		if i >= len(patchedLines) || original[i] == patchedLines[i] {
+			continue
+		}
+		fmt.Fprintf(&b, "@@ line %d @@\n-%s\n+%s\n", i+1, original[i], patchedLines[i])
+	}
+	return b.String(), nil
+}
+
+// applyFix dispatches a single diagnostic to the handler for its Code,
+// mutating lines in place. d.Owner names the top-level block (struct,
+// union, enum or service) the diagnostic is about; for diagnostics whose
+// Name identifies something declared inside a service function (function
+// arguments and throws clauses), d.Scope additionally names that function,
+// since a service's own declaration line never mentions its functions'
+// argument or exception names.
+func (f *Fixer) applyFix(lines []string, d Diagnostic) error {
+	switch d.Code {
+	case CodeUnionRequiredField:
+		start, end, ok := findBlock(lines, d.Owner)
+		if !ok {
+			return nil
+		}
+		return stripRequirednessIn(lines, start, end, d.Name, "required")
+	case CodeThrowsFieldRequired:
+		start, end, ok := f.findFunctionLines(lines, d.Owner, d.Scope)
+		if !ok {
+			return nil
+		}
+		return stripRequirednessIn(lines, start, end, d.Name, "required")
+	case CodeOptionalArgument:
+		start, end, ok := f.findFunctionLines(lines, d.Owner, d.Scope)
+		if !ok {
+			return nil
+		}
+		return stripRequirednessIn(lines, start, end, d.Name, "optional")
+	case CodeNonPositiveFieldID:
+		start, end, ok := findBlock(lines, d.Owner)
+		if !ok {
+			return nil
+		}
+		return renumberFieldIn(lines, start, end, d.Name)
+	case CodeNonPositiveArgID:
+		start, end, ok := f.findFunctionLines(lines, d.Owner, d.Scope)
+		if !ok {
+			return nil
+		}
+		return renumberFieldIn(lines, start, end, d.Name)
+	}
+	return nil
+}
+
+var declRegexp = regexp.MustCompile(`\b(struct|union|exception|enum|service)\s+`)
+
+// findBlock returns the [start, end) line range (0-indexed, end exclusive)
+// of the brace-delimited block introducing name, e.g. "struct Foo {" ...
+// "}". It returns ok=false if no such block is found.
+func findBlock(lines []string, name string) (start, end int, ok bool) {
+	nameRE := regexp.MustCompile(`\b` + regexp.QuoteMeta(name) + `\b`)
+	for i, line := range lines {
+		if !declRegexp.MatchString(line) || !nameRE.MatchString(line) {
+			continue
+		}
+		depth := strings.Count(line, "{") - strings.Count(line, "}")
+		if depth <= 0 {
+			continue
+		}
+		for j := i + 1; j < len(lines); j++ {
+			depth += strings.Count(lines[j], "{") - strings.Count(lines[j], "}")
+			if depth <= 0 {
+				return i, j + 1, true
+			}
+		}
+		return i, len(lines), true
+	}
+	return 0, 0, false
+}
+
+// findDeclLine returns the index, within [start, end), of the line that
+// declares name (as a whole word), or -1 if none is found.
+func findDeclLine(lines []string, start, end int, name string) int {
+	nameRE := regexp.MustCompile(`\b` + regexp.QuoteMeta(name) + `\b`)
+	for i := start; i < end; i++ {
+		if nameRE.MatchString(lines[i]) {
+			return i
+		}
+	}
+	return -1
+}
+
+// findFunctionLines returns the [start, end) line range, within service
+// serviceName's block, that belongs to funcName's declaration: from its
+// "returnType funcName(" line up to (but not including) whichever line
+// comes first out of the service's next function declaration or the end of
+// the service block. That's wide enough to contain funcName's whole
+// argument and throws lists even when they span multiple lines.
+func (f *Fixer) findFunctionLines(lines []string, serviceName, funcName string) (start, end int, ok bool) {
+	svcStart, svcEnd, ok := findBlock(lines, serviceName)
+	if !ok {
+		return 0, 0, false
+	}
+
+	var svc *parser.Service
+	for _, s := range f.Thrift.Services {
+		if s.Name == serviceName {
+			svc = s
+			break
+		}
+	}
+	if svc == nil {
+		return 0, 0, false
+	}
+
+	fnStartRE := regexp.MustCompile(`\b` + regexp.QuoteMeta(funcName) + `\s*\(`)
+	fnStart := -1
+	for i := svcStart; i < svcEnd; i++ {
+		if fnStartRE.MatchString(lines[i]) {
+			fnStart = i
+			break
+		}
+	}
+	if fnStart < 0 {
+		return 0, 0, false
+	}
+
+	fnEnd := svcEnd
+	for _, other := range svc.Functions {
+		if other.Name == funcName {
+			continue
+		}
+		if i := findDeclLine(lines, fnStart+1, svcEnd, other.Name); i > fnStart && i < fnEnd {
+			fnEnd = i
+		}
+	}
+	return fnStart, fnEnd, true
+}
+
+// stripRequirednessIn removes the keyword ("required" or "optional") from
+// the line declaring name within [start, end).
+func stripRequirednessIn(lines []string, start, end int, name, keyword string) error {
+	i := findDeclLine(lines, start, end, name)
+	if i < 0 {
+		return nil
+	}
+	kwRE := regexp.MustCompile(`\b` + keyword + `\s+`)
+	lines[i] = kwRE.ReplaceAllString(lines[i], "")
+	return nil
+}
+
+var fieldIDRegexp = regexp.MustCompile(`^(\s*)(-?\d+)(\s*:.*)$`)
+
+// renumberFieldIn replaces the field/argument ID on name's declaration line
+// with the smallest positive ID not used anywhere in [start, end).
+func renumberFieldIn(lines []string, start, end int, name string) error {
+	i := findDeclLine(lines, start, end, name)
+	if i < 0 {
+		return nil
+	}
+
+	next := nextFreeFieldID(lines, start, end)
+	m := fieldIDRegexp.FindStringSubmatch(lines[i])
+	if m == nil {
+		return nil
+	}
+	lines[i] = m[1] + strconv.Itoa(next) + m[3]
+	return nil
+}
+
+// nextFreeFieldID scans [start, end) for "<id>:" prefixes and returns the
+// smallest positive integer not already used.
+func nextFreeFieldID(lines []string, start, end int) int {
+	used := make(map[int]bool)
+	for i := start; i < end; i++ {
+		m := fieldIDRegexp.FindStringSubmatch(lines[i])
+		if m == nil {
+			continue
+		}
+		if id, err := strconv.Atoi(m[2]); err == nil {
+			used[id] = true
+		}
+	}
+	next := 1
+	for used[next] {
+		next++
+	}
+	return next
+}
+
+var enumValueRegexp = regexp.MustCompile(`^(\s*\w+\s*=\s*)(-?\d+)(.*)$`)
+
+// fixDuplicateEnumValues finds enum values that collide on the same integer
+// and rewrites every duplicate after the first to the next free value in
+// that enum, suggesting "= N" the way a reviewer would in code review.
+func (f *Fixer) fixDuplicateEnumValues(lines []string) error {
+	for _, e := range f.Thrift.Enums {
+		start, end, ok := findBlock(lines, e.Name)
+		if !ok {
+			continue
+		}
+		seen := make(map[int64]bool)
+		used := make(map[int64]bool)
+		for _, v := range e.Values {
+			used[v.Value] = true
+		}
+		next := int64(0)
+		freeValue := func() int64 {
+			for used[next] {
+				next++
+			}
+			used[next] = true
+			return next
+		}
+		for _, v := range e.Values {
+			if !seen[v.Value] {
+				seen[v.Value] = true
+				continue
+			}
+			i := findDeclLine(lines, start, end, v.Name)
+			if i < 0 {
+				continue
+			}
+			m := enumValueRegexp.FindStringSubmatch(lines[i])
+			if m == nil {
+				continue
+			}
+			lines[i] = m[1] + strconv.FormatInt(freeValue(), 10) + m[3]
+		}
+	}
+	return nil
+}
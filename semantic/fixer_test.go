@@ -0,0 +1,106 @@
+// Copyright 2021 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package semantic
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/cloudwego/thriftgo/parser"
+)
+
+// TestFixerFixesFunctionScopedDiagnostics is the regression test for the
+// Owner/Scope bug: CodeNonPositiveArgID, CodeThrowsFieldRequired and
+// CodeOptionalArgument all point at something declared inside a function,
+// which findBlock alone can never locate since a service's opening line
+// never mentions its functions' names.
+func TestFixerFixesFunctionScopedDiagnostics(t *testing.T) {
+	source := `service Foo {
+  void bar(
+    1: optional string name,
+    -1: i32 count,
+  ) throws (
+    1: required Err oops,
+  )
+}
+`
+	th := &parser.Thrift{
+		Filename: "x.thrift",
+		Services: []*parser.Service{{
+			Name: "Foo",
+			Functions: []*parser.Function{{
+				Name: "bar",
+			}},
+		}},
+	}
+
+	diags := []Diagnostic{
+		{Code: CodeOptionalArgument, Name: "name", Owner: "Foo", Scope: "bar"},
+		{Code: CodeNonPositiveArgID, Name: "count", Owner: "Foo", Scope: "bar"},
+		{Code: CodeThrowsFieldRequired, Name: "oops", Owner: "Foo", Scope: "bar"},
+	}
+
+	f := NewFixer(th, []byte(source))
+	patched, err := f.Fix(diags)
+	if err != nil {
+		t.Fatalf("Fix: unexpected error: %v", err)
+	}
+	out := string(patched)
+
+	if strings.Contains(out, "optional string name") {
+		t.Errorf("Fix: expected \"optional\" stripped from the argument, got:\n%s", out)
+	}
+	if strings.Contains(out, "-1: i32 count") {
+		t.Errorf("Fix: expected argument ID -1 renumbered to a free positive ID, got:\n%s", out)
+	}
+	if strings.Contains(out, "required Err oops") {
+		t.Errorf("Fix: expected \"required\" stripped from the throws field, got:\n%s", out)
+	}
+}
+
+// TestFixerDeduplicatesEnumValues is the regression test for declRegexp
+// missing "enum": fixDuplicateEnumValues called findBlock(lines, e.Name) for
+// every enum, which could never match an "enum Foo {" line before enum was
+// added to the alternation.
+func TestFixerDeduplicatesEnumValues(t *testing.T) {
+	source := `enum Color {
+  RED = 1,
+  GREEN = 1,
+  BLUE = 2,
+}
+`
+	th := &parser.Thrift{
+		Filename: "x.thrift",
+		Enums: []*parser.Enum{{
+			Name: "Color",
+			Values: []*parser.EnumValue{
+				{Name: "RED", Value: 1},
+				{Name: "GREEN", Value: 1},
+				{Name: "BLUE", Value: 2},
+			},
+		}},
+	}
+
+	f := NewFixer(th, []byte(source))
+	patched, err := f.Fix(nil)
+	if err != nil {
+		t.Fatalf("Fix: unexpected error: %v", err)
+	}
+	out := string(patched)
+
+	if strings.Contains(out, "GREEN = 1") {
+		t.Errorf("Fix: expected GREEN's duplicate value rewritten, got:\n%s", out)
+	}
+}
@@ -0,0 +1,294 @@
+// Copyright 2021 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package semantic
+
+import (
+	"sync"
+
+	"github.com/cloudwego/thriftgo/parser"
+)
+
+// Rule is a single semantic check that can be enabled, disabled, or have its
+// severity overridden independently of the others. Built-in checks
+// (CheckGlobals, CheckEnums, ...) are themselves registered as rules; rules
+// contributed by third-party packages via Register work the same way.
+type Rule interface {
+	// ID uniquely identifies the rule, e.g. "globals" or "naming".
+	ID() string
+	// Description explains what the rule checks, for use in docs and -list
+	// style CLI output.
+	Description() string
+	// Check runs the rule against a single file, reporting findings through
+	// emit instead of returning them directly so a rule can report any
+	// number of diagnostics. A returned error aborts checking of the
+	// current file, matching the historical fail-fast behavior of grammar
+	// errors.
+	Check(t *parser.Thrift, emit func(Diagnostic)) error
+}
+
+// Registry holds the set of rules a checker consults, in registration order.
+// The zero value is not usable; use NewRegistry, or the package-level
+// DefaultRegistry populated by the built-in rules and any third-party
+// Register calls.
+type Registry struct {
+	mu    sync.Mutex
+	rules []Rule
+	byID  map[string]Rule
+}
+
+// NewRegistry creates an empty rule registry.
+func NewRegistry() *Registry {
+	return &Registry{byID: make(map[string]Rule)}
+}
+
+// Register adds a rule to the registry. It panics if a rule with the same
+// ID is already registered, since that almost always indicates two packages
+// accidentally picked the same name.
+func (r *Registry) Register(rule Rule) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.byID[rule.ID()]; ok {
+		panic("semantic: rule already registered: " + rule.ID())
+	}
+	r.byID[rule.ID()] = rule
+	r.rules = append(r.rules, rule)
+}
+
+// Rules returns the registered rules in registration order.
+func (r *Registry) Rules() []Rule {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	out := make([]Rule, len(r.rules))
+	copy(out, r.rules)
+	return out
+}
+
+// Lookup returns the rule registered under id, if any.
+func (r *Registry) Lookup(id string) (Rule, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	rule, ok := r.byID[id]
+	return rule, ok
+}
+
+// DefaultRegistry is the registry consulted by NewChecker when Options
+// doesn't specify one. Third-party packages register ecosystem rules
+// (naming conventions, reserved-field checks, forbidden types, ...) against
+// it from their own init() functions:
+//
+//	func init() {
+//		semantic.Register(mypkg.NoForbiddenTypesRule)
+//	}
+var DefaultRegistry = NewRegistry()
+
+// Register adds rule to DefaultRegistry. It is a convenience wrapper for
+// third-party packages that only ever target the default registry.
+func Register(rule Rule) {
+	DefaultRegistry.Register(rule)
+}
+
+func init() {
+	Register(globalsRule{})
+	Register(enumsRule{})
+	Register(structLikesRule{})
+	Register(unionsRule{})
+	Register(functionsRule{})
+}
+
+// registry returns the Registry this checker consults: Options.Registry if
+// set, otherwise DefaultRegistry.
+func (c *checker) registry() *Registry {
+	if c.Registry != nil {
+		return c.Registry
+	}
+	return DefaultRegistry
+}
+
+// activeRules filters the registry's rules by Options.EnabledRules and
+// Options.DisabledRules. An empty EnabledRules means "every rule that's on
+// by default"; DisabledRules always wins over EnabledRules for a given ID.
+//
+// A rule can opt out of running by default (e.g. the style rules in
+// style_rules.go) by implementing an optional DefaultEnabled() bool method
+// that returns false; it then only runs once its ID is added to
+// EnabledRules.
+func (c *checker) activeRules() []Rule {
+	all := c.registry().Rules()
+
+	var enabled map[string]bool
+	if len(c.EnabledRules) > 0 {
+		enabled = make(map[string]bool, len(c.EnabledRules))
+		for _, id := range c.EnabledRules {
+			enabled[id] = true
+		}
+	}
+	disabled := make(map[string]bool, len(c.DisabledRules))
+	for _, id := range c.DisabledRules {
+		disabled[id] = true
+	}
+
+	active := make([]Rule, 0, len(all))
+	for _, rule := range all {
+		if disabled[rule.ID()] {
+			continue
+		}
+		optedIn := enabled[rule.ID()]
+		if enabled != nil && !optedIn {
+			continue
+		}
+		if !optedIn {
+			if opt, ok := rule.(interface{ DefaultEnabled() bool }); ok && !opt.DefaultEnabled() {
+				continue
+			}
+		}
+		active = append(active, rule)
+	}
+	return active
+}
+
+// boundRule is implemented by the package's own built-in rules so runRule
+// can run them as bound methods on the live checker (which matters because
+// CheckUnions/CheckFunctions honor c.FixWarnings) instead of dispatching on
+// rule.ID(), which would silently run the wrong logic if a third party ever
+// registered a rule of their own under one of the built-in IDs on a custom
+// Registry. checkBound is unexported, so only this package can implement it.
+type boundRule interface {
+	Rule
+	checkBound(c *checker, t *parser.Thrift, emit func(Diagnostic)) error
+}
+
+// runRule executes rule against t, applying Options.SeverityOverrides (keyed
+// by Diagnostic.Code) to whatever it emits.
+func (c *checker) runRule(rule Rule, t *parser.Thrift) (diags []Diagnostic, err error) {
+	emit := func(d Diagnostic) {
+		if sev, ok := c.SeverityOverrides[d.Code]; ok {
+			d.Severity = sev
+		}
+		if d.Severity == SeverityIgnore {
+			return
+		}
+		diags = append(diags, d)
+	}
+
+	if br, ok := rule.(boundRule); ok {
+		err = br.checkBound(c, t, emit)
+	} else {
+		err = rule.Check(t, emit)
+	}
+	return diags, err
+}
+
+// globalsRule, enumsRule, structLikesRule, unionsRule and functionsRule
+// adapt the original hard-coded check functions to the Rule interface so
+// they are discoverable (ID, Description) and can be enabled/disabled like
+// any other rule. Each also implements boundRule so runRule binds their
+// execution to the live checker; Check itself (using a fresh, default-
+// Options checker) is only exercised when a rule is run outside of a
+// checker, e.g. a custom Registry walked directly.
+
+type globalsRule struct{}
+
+func (globalsRule) ID() string          { return "globals" }
+func (globalsRule) Description() string { return "duplicated names in the global scope" }
+func (globalsRule) Check(t *parser.Thrift, emit func(Diagnostic)) error {
+	diags, err := (&checker{}).CheckGlobals(t)
+	for _, d := range diags {
+		emit(d)
+	}
+	return err
+}
+func (globalsRule) checkBound(c *checker, t *parser.Thrift, emit func(Diagnostic)) error {
+	diags, err := c.CheckGlobals(t)
+	for _, d := range diags {
+		emit(d)
+	}
+	return err
+}
+
+type enumsRule struct{}
+
+func (enumsRule) ID() string          { return "enums" }
+func (enumsRule) Description() string { return "duplicated or out-of-range enum values" }
+func (enumsRule) Check(t *parser.Thrift, emit func(Diagnostic)) error {
+	diags, err := (&checker{}).CheckEnums(t)
+	for _, d := range diags {
+		emit(d)
+	}
+	return err
+}
+func (enumsRule) checkBound(c *checker, t *parser.Thrift, emit func(Diagnostic)) error {
+	diags, err := c.CheckEnums(t)
+	for _, d := range diags {
+		emit(d)
+	}
+	return err
+}
+
+type structLikesRule struct{}
+
+func (structLikesRule) ID() string          { return "struct-likes" }
+func (structLikesRule) Description() string { return "duplicated or non-positive field IDs and names" }
+func (structLikesRule) Check(t *parser.Thrift, emit func(Diagnostic)) error {
+	diags, err := (&checker{}).CheckStructLikes(t)
+	for _, d := range diags {
+		emit(d)
+	}
+	return err
+}
+func (structLikesRule) checkBound(c *checker, t *parser.Thrift, emit func(Diagnostic)) error {
+	diags, err := c.CheckStructLikes(t)
+	for _, d := range diags {
+		emit(d)
+	}
+	return err
+}
+
+type unionsRule struct{}
+
+func (unionsRule) ID() string          { return "unions" }
+func (unionsRule) Description() string { return "union fields that should be optional" }
+func (unionsRule) Check(t *parser.Thrift, emit func(Diagnostic)) error {
+	diags, err := (&checker{}).CheckUnions(t)
+	for _, d := range diags {
+		emit(d)
+	}
+	return err
+}
+func (unionsRule) checkBound(c *checker, t *parser.Thrift, emit func(Diagnostic)) error {
+	diags, err := c.CheckUnions(t)
+	for _, d := range diags {
+		emit(d)
+	}
+	return err
+}
+
+type functionsRule struct{}
+
+func (functionsRule) ID() string          { return "functions" }
+func (functionsRule) Description() string { return "invalid oneway/argument/throws declarations" }
+func (functionsRule) Check(t *parser.Thrift, emit func(Diagnostic)) error {
+	diags, err := (&checker{}).CheckFunctions(t)
+	for _, d := range diags {
+		emit(d)
+	}
+	return err
+}
+func (functionsRule) checkBound(c *checker, t *parser.Thrift, emit func(Diagnostic)) error {
+	diags, err := c.CheckFunctions(t)
+	for _, d := range diags {
+		emit(d)
+	}
+	return err
+}
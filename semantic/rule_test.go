@@ -0,0 +1,81 @@
+// Copyright 2021 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package semantic
+
+import (
+	"testing"
+
+	"github.com/cloudwego/thriftgo/parser"
+)
+
+// fakeGlobalsRule deliberately collides with the built-in "globals" ID to
+// verify a custom Registry's rule wins over the built-in logic of the same
+// name, rather than runRule silently running CheckGlobals instead.
+type fakeGlobalsRule struct{}
+
+func (fakeGlobalsRule) ID() string          { return "globals" }
+func (fakeGlobalsRule) Description() string { return "test double for globals" }
+func (fakeGlobalsRule) Check(t *parser.Thrift, emit func(Diagnostic)) error {
+	emit(Diagnostic{Severity: SeverityWarning, Code: "TEST_FAKE", File: t.Filename, Message: "from fakeGlobalsRule"})
+	return nil
+}
+
+func TestRunRuleDispatchesByIdentityNotID(t *testing.T) {
+	reg := NewRegistry()
+	reg.Register(fakeGlobalsRule{})
+
+	c := &checker{Options{Registry: reg}}
+	th := &parser.Thrift{Filename: "x.thrift"}
+
+	diags, err := c.runRule(fakeGlobalsRule{}, th)
+	if err != nil {
+		t.Fatalf("runRule: unexpected error: %v", err)
+	}
+	if len(diags) != 1 || diags[0].Code != "TEST_FAKE" {
+		t.Fatalf("runRule: expected the custom rule's own diagnostic, got %v", diags)
+	}
+}
+
+func TestActiveRulesRespectsEnabledAndDisabled(t *testing.T) {
+	reg := NewRegistry()
+	reg.Register(globalsRule{})
+	reg.Register(nonPortableTypesRule{}) // DefaultEnabled() == false
+
+	c := &checker{Options{Registry: reg}}
+	active := c.activeRules()
+	if len(active) != 1 || active[0].ID() != "globals" {
+		t.Fatalf("activeRules: expected only the default-enabled rule, got %v", ruleIDs(active))
+	}
+
+	c = &checker{Options{Registry: reg, EnabledRules: []string{"non-portable-types"}}}
+	active = c.activeRules()
+	if len(active) != 1 || active[0].ID() != "non-portable-types" {
+		t.Fatalf("activeRules: expected EnabledRules to restrict checking to non-portable-types, got %v", ruleIDs(active))
+	}
+
+	c = &checker{Options{Registry: reg, DisabledRules: []string{"globals"}}}
+	active = c.activeRules()
+	if len(active) != 0 {
+		t.Fatalf("activeRules: expected DisabledRules to win, got %v", ruleIDs(active))
+	}
+}
+
+func ruleIDs(rules []Rule) []string {
+	ids := make([]string, len(rules))
+	for i, r := range rules {
+		ids[i] = r.ID()
+	}
+	return ids
+}
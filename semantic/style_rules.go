@@ -0,0 +1,270 @@
+// Copyright 2021 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package semantic
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/cloudwego/thriftgo/parser"
+)
+
+// This file adds opt-in style rules on top of the grammar checks in
+// checker.go. They are registered with DefaultRegistry so they're
+// discoverable (ID, Description), but DefaultEnabled reports false for all
+// of them: a project turns one on by adding its ID to Options.EnabledRules.
+
+const (
+	CodeBadStructName     = "SEM101_BadStructName"
+	CodeBadEnumValueName  = "SEM102_BadEnumValueName"
+	CodeBadFieldName      = "SEM103_BadFieldName"
+	CodeBinaryMapKey      = "SEM104_BinaryMapKey"
+	CodeUnusedInclude     = "SEM105_UnusedInclude"
+	CodeReservedEnumValue = "SEM106_ReservedEnumValue"
+)
+
+func init() {
+	Register(NewNamingRule(NamingOptions{}))
+	Register(nonPortableTypesRule{})
+	Register(unusedIncludeRule{})
+	Register(reservedEnumValueRule{})
+}
+
+// walkTypes calls visit for every parser.Type referenced in t: typedef
+// targets, constant types, struct/union/exception field types, and service
+// function argument/return/throws types.
+func walkTypes(t *parser.Thrift, visit func(*parser.Type)) {
+	for _, td := range t.Typedefs {
+		visit(td.Type)
+	}
+	for _, c := range t.Constants {
+		visit(c.Type)
+	}
+	for _, s := range t.GetStructLikes() {
+		for _, f := range s.Fields {
+			visit(f.Type)
+		}
+	}
+	for _, svc := range t.Services {
+		for _, fn := range svc.Functions {
+			visit(fn.FunctionType)
+			for _, a := range fn.Arguments {
+				visit(a.Type)
+			}
+			for _, a := range fn.Throws {
+				visit(a.Type)
+			}
+		}
+	}
+}
+
+// walkTypeTree calls visit for typ and recursively for both its KeyType and
+// ValueType (a map's key and value are independent branches, not a single
+// chain, so both need their own recursive call rather than an if/else that
+// only ever follows one).
+func walkTypeTree(typ *parser.Type, visit func(*parser.Type)) {
+	if typ == nil {
+		return
+	}
+	visit(typ)
+	walkTypeTree(typ.KeyType, visit)
+	walkTypeTree(typ.ValueType, visit)
+}
+
+// NamingOptions configures NamingRule. A nil field keeps the default
+// regexp for that name class.
+type NamingOptions struct {
+	// StructRegexp matches struct/union/exception/service/enum names.
+	// Default: PascalCase.
+	StructRegexp *regexp.Regexp
+	// EnumValueRegexp matches enum value names. Default: SCREAMING_SNAKE_CASE.
+	EnumValueRegexp *regexp.Regexp
+	// FieldRegexp matches struct/union/exception field names. Default:
+	// lowerCamelCase.
+	FieldRegexp *regexp.Regexp
+}
+
+var (
+	defaultStructNameRegexp    = regexp.MustCompile(`^[A-Z][A-Za-z0-9]*$`)
+	defaultEnumValueNameRegexp = regexp.MustCompile(`^[A-Z][A-Z0-9]*(_[A-Z0-9]+)*$`)
+	defaultFieldNameRegexp     = regexp.MustCompile(`^[a-z][A-Za-z0-9]*$`)
+)
+
+type namingRule struct {
+	opts NamingOptions
+}
+
+// NewNamingRule creates the "naming" rule with the given overrides. Pass a
+// zero-value NamingOptions to use the defaults (PascalCase names,
+// SCREAMING_SNAKE_CASE enum values, lowerCamelCase fields).
+func NewNamingRule(opts NamingOptions) Rule {
+	if opts.StructRegexp == nil {
+		opts.StructRegexp = defaultStructNameRegexp
+	}
+	if opts.EnumValueRegexp == nil {
+		opts.EnumValueRegexp = defaultEnumValueNameRegexp
+	}
+	if opts.FieldRegexp == nil {
+		opts.FieldRegexp = defaultFieldNameRegexp
+	}
+	return &namingRule{opts: opts}
+}
+
+func (r *namingRule) ID() string { return "naming" }
+func (r *namingRule) Description() string {
+	return "struct/service/enum names, enum values, and field names follow naming conventions"
+}
+func (r *namingRule) DefaultEnabled() bool { return false }
+
+func (r *namingRule) Check(t *parser.Thrift, emit func(Diagnostic)) error {
+	badName := func(code, kind, name string) {
+		emit(Diagnostic{
+			Severity: SeverityWarning,
+			Code:     code,
+			File:     t.Filename,
+			Message:  fmt.Sprintf("%s name %q does not match the naming convention", kind, name),
+		})
+	}
+
+	for _, s := range t.GetStructLikes() {
+		if !r.opts.StructRegexp.MatchString(s.Name) {
+			badName(CodeBadStructName, s.Category, s.Name)
+		}
+		for _, f := range s.Fields {
+			if !r.opts.FieldRegexp.MatchString(f.Name) {
+				badName(CodeBadFieldName, "field", f.Name)
+			}
+		}
+	}
+	for _, svc := range t.Services {
+		if !r.opts.StructRegexp.MatchString(svc.Name) {
+			badName(CodeBadStructName, "service", svc.Name)
+		}
+	}
+	for _, e := range t.Enums {
+		if !r.opts.StructRegexp.MatchString(e.Name) {
+			badName(CodeBadStructName, "enum", e.Name)
+		}
+		for _, v := range e.Values {
+			if !r.opts.EnumValueRegexp.MatchString(v.Name) {
+				badName(CodeBadEnumValueName, "enum value", v.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// nonPortableTypesRule flags Thrift patterns that are legal in the grammar
+// but behave inconsistently (or fail outright) across generated-code
+// languages, starting with binary used as a map key: several target
+// languages can't hash or order a byte string, so they either reject the
+// generated code or silently fall back to reference equality.
+type nonPortableTypesRule struct{}
+
+func (nonPortableTypesRule) ID() string { return "non-portable-types" }
+func (nonPortableTypesRule) Description() string {
+	return "Thrift types that don't port cleanly across generated-code languages, e.g. binary map keys"
+}
+func (nonPortableTypesRule) DefaultEnabled() bool { return false }
+
+func (nonPortableTypesRule) Check(t *parser.Thrift, emit func(Diagnostic)) error {
+	walkTypes(t, func(typ *parser.Type) {
+		walkTypeTree(typ, func(cur *parser.Type) {
+			if cur.Name == "map" && cur.KeyType != nil && cur.KeyType.Name == "binary" {
+				emit(Diagnostic{
+					Severity: SeverityWarning,
+					Code:     CodeBinaryMapKey,
+					File:     t.Filename,
+					Message:  "binary used as a map key is not portable across all target languages",
+				})
+			}
+		})
+	})
+	return nil
+}
+
+// unusedIncludeRule flags entries in t.Includes whose alias is never used
+// to qualify a type anywhere in t.
+type unusedIncludeRule struct{}
+
+func (unusedIncludeRule) ID() string          { return "unused-include" }
+func (unusedIncludeRule) Description() string { return "includes whose symbols are never referenced" }
+func (unusedIncludeRule) DefaultEnabled() bool { return false }
+
+func (unusedIncludeRule) Check(t *parser.Thrift, emit func(Diagnostic)) error {
+	used := make(map[string]bool)
+	walkTypes(t, func(typ *parser.Type) {
+		// descend into container types so e.g. list<common.Foo> or
+		// map<string, common.Bar> count common as used, not just direct
+		// references.
+		walkTypeTree(typ, func(cur *parser.Type) {
+			if alias, _, ok := strings.Cut(cur.Name, "."); ok {
+				used[alias] = true
+			}
+		})
+	})
+
+	for _, inc := range t.Includes {
+		alias := strings.TrimSuffix(filepath.Base(inc.Path), filepath.Ext(inc.Path))
+		if !used[alias] {
+			emit(Diagnostic{
+				Severity: SeverityWarning,
+				Code:     CodeUnusedInclude,
+				File:     t.Filename,
+				Message:  fmt.Sprintf("include %q is never referenced", inc.Path),
+			})
+		}
+	}
+	return nil
+}
+
+// reservedEnumValueRule flags enum values that collide with sentinels
+// reserved for protobuf-interop (0, the protobuf default/unset value) or
+// that are negative, which some target languages treat as invalid.
+type reservedEnumValueRule struct{}
+
+func (reservedEnumValueRule) ID() string { return "reserved-enum-value" }
+func (reservedEnumValueRule) Description() string {
+	return "enum values of 0 (protobuf-interop sentinel) or negative"
+}
+func (reservedEnumValueRule) DefaultEnabled() bool { return false }
+
+func (reservedEnumValueRule) Check(t *parser.Thrift, emit func(Diagnostic)) error {
+	for _, e := range t.Enums {
+		for _, v := range e.Values {
+			switch {
+			case v.Value == 0:
+				emit(Diagnostic{
+					Severity: SeverityWarning,
+					Code:     CodeReservedEnumValue,
+					File:     t.Filename,
+					Message: fmt.Sprintf("enum %s value %s is 0, which protobuf reserves for the unset/default case",
+						e.Name, v.Name),
+				})
+			case v.Value < 0:
+				emit(Diagnostic{
+					Severity: SeverityWarning,
+					Code:     CodeReservedEnumValue,
+					File:     t.Filename,
+					Message: fmt.Sprintf("enum %s value %s is negative (%d), which some target languages reject",
+						e.Name, v.Name, v.Value),
+				})
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,128 @@
+// Copyright 2021 CloudWeGo Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package semantic
+
+import (
+	"testing"
+
+	"github.com/cloudwego/thriftgo/parser"
+)
+
+// TestUnusedIncludeRuleContainerTypes guards against a false positive in
+// unusedIncludeRule: an include referenced only inside a list/map must still
+// count as used.
+func TestUnusedIncludeRuleContainerTypes(t *testing.T) {
+	th := &parser.Thrift{
+		Filename: "x.thrift",
+		Includes: []*parser.Include{
+			{Path: "common.thrift"},
+		},
+		Structs: []*parser.StructLike{
+			{Category: "struct", Name: "Foo", Fields: []*parser.Field{
+				{ID: 1, Name: "bars", Type: &parser.Type{
+					Name:      "list",
+					ValueType: &parser.Type{Name: "common.Bar"},
+				}},
+			}},
+		},
+	}
+
+	var diags []Diagnostic
+	if err := (unusedIncludeRule{}).Check(th, func(d Diagnostic) { diags = append(diags, d) }); err != nil {
+		t.Fatalf("Check: unexpected error: %v", err)
+	}
+	if len(diags) != 0 {
+		t.Fatalf("Check: include used via list<common.Bar> must not be flagged unused, got %v", diags)
+	}
+}
+
+// TestUnusedIncludeRuleMapValueType covers the case the container-descent
+// bugfix missed the first time: map<string, common.Bar> only reaches
+// common.Bar through ValueType, since KeyType ("string") is non-nil and an
+// if/else chain would stop there.
+func TestUnusedIncludeRuleMapValueType(t *testing.T) {
+	th := &parser.Thrift{
+		Filename: "x.thrift",
+		Includes: []*parser.Include{
+			{Path: "common.thrift"},
+		},
+		Structs: []*parser.StructLike{
+			{Category: "struct", Name: "Foo", Fields: []*parser.Field{
+				{ID: 1, Name: "bars", Type: &parser.Type{
+					Name:      "map",
+					KeyType:   &parser.Type{Name: "string"},
+					ValueType: &parser.Type{Name: "common.Bar"},
+				}},
+			}},
+		},
+	}
+
+	var diags []Diagnostic
+	if err := (unusedIncludeRule{}).Check(th, func(d Diagnostic) { diags = append(diags, d) }); err != nil {
+		t.Fatalf("Check: unexpected error: %v", err)
+	}
+	if len(diags) != 0 {
+		t.Fatalf("Check: include used via map<string, common.Bar> must not be flagged unused, got %v", diags)
+	}
+}
+
+// TestNonPortableTypesRuleBinaryMapKeyInValuePosition covers the same
+// container-descent bug in nonPortableTypesRule: a binary map key nested in
+// a value position, e.g. map<string, map<binary,i32>>, must still be found.
+func TestNonPortableTypesRuleBinaryMapKeyInValuePosition(t *testing.T) {
+	th := &parser.Thrift{
+		Filename: "x.thrift",
+		Structs: []*parser.StructLike{
+			{Category: "struct", Name: "Foo", Fields: []*parser.Field{
+				{ID: 1, Name: "bad", Type: &parser.Type{
+					Name:    "map",
+					KeyType: &parser.Type{Name: "string"},
+					ValueType: &parser.Type{
+						Name:    "map",
+						KeyType: &parser.Type{Name: "binary"},
+						ValueType: &parser.Type{
+							Name: "i32",
+						},
+					},
+				}},
+			}},
+		},
+	}
+
+	var diags []Diagnostic
+	if err := (nonPortableTypesRule{}).Check(th, func(d Diagnostic) { diags = append(diags, d) }); err != nil {
+		t.Fatalf("Check: unexpected error: %v", err)
+	}
+	if len(diags) != 1 || diags[0].Code != CodeBinaryMapKey {
+		t.Fatalf("Check: expected one CodeBinaryMapKey diagnostic for the nested map, got %v", diags)
+	}
+}
+
+func TestUnusedIncludeRuleTrulyUnused(t *testing.T) {
+	th := &parser.Thrift{
+		Filename: "x.thrift",
+		Includes: []*parser.Include{
+			{Path: "common.thrift"},
+		},
+	}
+
+	var diags []Diagnostic
+	if err := (unusedIncludeRule{}).Check(th, func(d Diagnostic) { diags = append(diags, d) }); err != nil {
+		t.Fatalf("Check: unexpected error: %v", err)
+	}
+	if len(diags) != 1 || diags[0].Code != CodeUnusedInclude {
+		t.Fatalf("Check: expected a single CodeUnusedInclude diagnostic, got %v", diags)
+	}
+}